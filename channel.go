@@ -0,0 +1,381 @@
+package gosocketio
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/integration-system/golang-socketio/transport"
+)
+
+// Engine.IO packet type prefixes.
+const (
+	engineIOOpen    = '0'
+	engineIOClose   = '1'
+	engineIOPing    = '2'
+	engineIOPong    = '3'
+	engineIOMessage = '4'
+)
+
+// Socket.IO packet type prefixes, carried inside an engineIOMessage packet.
+const (
+	socketIOConnect    = '0'
+	socketIODisconnect = '1'
+	socketIOEvent      = '2'
+	socketIOAck        = '3'
+)
+
+var errChannelNotAlive = errors.New("gosocketio: channel is not alive")
+
+// wsTextMessage mirrors gorilla/websocket's TextMessage frame type, kept
+// local so this file does not need to import the transport's websocket
+// dependency directly.
+const wsTextMessage = 1
+
+// Channel represents one Engine.IO/Socket.IO connection and the raw
+// send/receive plumbing around it. Client embeds it.
+type Channel struct {
+	conn transport.Connection
+
+	// Id is the Engine.IO session id assigned by the server on open.
+	Id string
+
+	// PingInterval/PongTimeout configure dead-connection detection; see
+	// the read loop in inLoop.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+
+	out  chan string
+	done chan struct{}
+
+	aliveLock sync.Mutex
+	alive     bool
+
+	ackLock    sync.Mutex
+	ackCounter int
+	ackWaiters map[int]func(error)
+
+	disconnectOnce sync.Once
+
+	// onDisconnection is called exactly once, with the error that ended
+	// the connection (nil on a clean, requested close).
+	onDisconnection func(*Channel, error)
+	// onError reports a transport-level read/write error as it happens,
+	// distinct from onDisconnection which only fires once the loops
+	// have actually torn down.
+	onError func(error)
+	// onRawMessage taps every raw message before Engine.IO decoding.
+	onRawMessage func([]byte)
+}
+
+// disconnect marks the channel dead and notifies onError/onDisconnection
+// exactly once, regardless of which loop (inLoop or outLoop) noticed the
+// failure first.
+func (c *Channel) disconnect(err error) {
+	c.disconnectOnce.Do(func() {
+		c.SetAlive(false)
+		close(c.done)
+		c.dropPendingAcks()
+		if err != nil && c.onError != nil {
+			c.onError(err)
+		}
+		if c.onDisconnection != nil {
+			c.onDisconnection(c, err)
+		}
+	})
+}
+
+// dropPendingAcks discards this channel's ack waiters without completing
+// them. Every waiter is the callback EmitWithAck registered, which
+// completes the client-level pendingAckTracker entry - that tracker, not
+// this channel, owns the replay-vs-expire decision for a reconnect-
+// eligible ack, so completing it here would race drain() on the next
+// successful dial into losing it.
+func (c *Channel) dropPendingAcks() {
+	c.ackLock.Lock()
+	c.ackWaiters = nil
+	c.ackLock.Unlock()
+}
+
+// resetForDial prepares the channel for a new connection generation: the
+// previous generation's out/done/ackWaiters must not be reused, since a
+// goroutine from that generation could still be selecting on them.
+func (c *Channel) resetForDial() {
+	c.out = make(chan string, 128)
+	c.done = make(chan struct{})
+	c.ackWaiters = make(map[int]func(error))
+	c.disconnectOnce = sync.Once{}
+}
+
+func (c *Channel) initChannel() {
+	c.resetForDial()
+}
+
+func (c *Channel) SetAlive(alive bool) {
+	c.aliveLock.Lock()
+	c.alive = alive
+	c.aliveLock.Unlock()
+}
+
+func (c *Channel) Alive() bool {
+	c.aliveLock.Lock()
+	defer c.aliveLock.Unlock()
+	return c.alive
+}
+
+// Emit sends a Socket.IO event packet.
+func (c *Channel) Emit(event string, args interface{}) error {
+	if !c.Alive() {
+		return errChannelNotAlive
+	}
+
+	packet, err := encodeEventPacket(event, args, 0)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.out <- packet:
+		return nil
+	default:
+		return errors.New("gosocketio: output queue full")
+	}
+}
+
+// emitWithAck sends a Socket.IO event packet tagged with a real ack id and
+// calls onAck once the server acknowledges it. onAck is also invoked with
+// an error if the packet cannot be encoded or queued. If the channel
+// disconnects before the ack arrives, onAck is not invoked: the waiter is
+// dropped by dropPendingAcks.
+func (c *Channel) emitWithAck(event string, args interface{}, onAck func(error)) error {
+	if !c.Alive() {
+		return errChannelNotAlive
+	}
+
+	c.ackLock.Lock()
+	if c.ackWaiters == nil {
+		c.ackLock.Unlock()
+		return errChannelNotAlive
+	}
+	c.ackCounter++
+	id := c.ackCounter
+	c.ackWaiters[id] = onAck
+	c.ackLock.Unlock()
+
+	packet, err := encodeEventPacket(event, args, id)
+	if err != nil {
+		c.completeAck(id, err)
+		return err
+	}
+
+	select {
+	case c.out <- packet:
+		return nil
+	default:
+		err := errors.New("gosocketio: output queue full")
+		c.completeAck(id, err)
+		return err
+	}
+}
+
+// completeAck resolves the waiter registered for id, if any: either a
+// real ack packet arrived, or emitWithAck failed to hand the packet off
+// immediately.
+func (c *Channel) completeAck(id int, err error) {
+	c.ackLock.Lock()
+	onAck, ok := c.ackWaiters[id]
+	if ok {
+		delete(c.ackWaiters, id)
+	}
+	c.ackLock.Unlock()
+
+	if ok && onAck != nil {
+		onAck(err)
+	}
+}
+
+func encodeEventPacket(event string, args interface{}, ackID int) (string, error) {
+	arr := []interface{}{event}
+	if args != nil {
+		arr = append(arr, args)
+	}
+	body, err := json.Marshal(arr)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := string(engineIOMessage) + string(socketIOEvent)
+	if ackID > 0 {
+		prefix += strconv.Itoa(ackID)
+	}
+	return prefix + string(body), nil
+}
+
+// closeChannel sends a Socket.IO disconnect packet and tears the
+// transport connection down.
+func closeChannel(c *Channel, m *methods) {
+	if c.Alive() {
+		select {
+		case c.out <- string(engineIOMessage) + string(socketIODisconnect):
+		default:
+		}
+		_ = c.conn.Close()
+	}
+	c.disconnect(nil)
+}
+
+// readDeadline returns how long the connection may go without receiving
+// any frame before it is considered dead, falling back to the Engine.IO v3
+// defaults if the channel wasn't configured with its own values.
+func (c *Channel) readDeadline() time.Duration {
+	pingInterval := c.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = defaultPingInterval
+	}
+	pongTimeout := c.PongTimeout
+	if pongTimeout <= 0 {
+		pongTimeout = defaultPongTimeout
+	}
+	return pingInterval + pongTimeout
+}
+
+func inLoop(c *Channel, m *methods) {
+	deadline := c.readDeadline()
+	_ = c.conn.SetReadDeadline(time.Now().Add(deadline))
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			// Covers both a transport-level read error and a read
+			// deadline firing because no frame arrived in time - a
+			// half-open TCP connection (common on mobile networks and
+			// NATs) is detected here instead of on the next failed write.
+			c.disconnect(err)
+			return
+		}
+
+		// Any inbound frame proves the connection is alive, not just a
+		// pong: a server that relies on the client answering its pings
+		// (rather than issuing its own) would otherwise have a healthy
+		// connection force-closed at the fixed deadline.
+		_ = c.conn.SetReadDeadline(time.Now().Add(deadline))
+
+		if c.onRawMessage != nil {
+			c.onRawMessage(data)
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case engineIOPing:
+			select {
+			case c.out <- string(engineIOPong):
+			default:
+			}
+		case engineIOOpen:
+			var open struct {
+				Sid string `json:"sid"`
+			}
+			if json.Unmarshal(data[1:], &open) == nil && open.Sid != "" {
+				c.Id = open.Sid
+			}
+		case engineIOPong:
+			// no-op: the deadline refresh above already covers it.
+		case engineIOClose:
+			c.disconnect(errors.New("gosocketio: server sent an Engine.IO close packet"))
+			return
+		case engineIOMessage:
+			handleSocketIOPacket(c, m, data[1:])
+		}
+	}
+}
+
+func handleSocketIOPacket(c *Channel, m *methods, payload []byte) {
+	if len(payload) == 0 {
+		return
+	}
+
+	switch payload[0] {
+	case socketIODisconnect:
+		c.disconnect(errors.New("gosocketio: server sent a Socket.IO disconnect packet"))
+	case socketIOAck:
+		rest := payload[1:]
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return
+		}
+		id, err := strconv.Atoi(string(rest[:i]))
+		if err != nil {
+			return
+		}
+		c.completeAck(id, nil)
+	case socketIOEvent:
+		rest := payload[1:]
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		body := rest[i:]
+
+		var arr []json.RawMessage
+		if err := json.Unmarshal(body, &arr); err != nil || len(arr) == 0 {
+			return
+		}
+		var event string
+		if err := json.Unmarshal(arr[0], &event); err != nil {
+			return
+		}
+		var args interface{}
+		if len(arr) > 1 {
+			_ = json.Unmarshal(arr[1], &args)
+		}
+		m.callEvent(c, event, args)
+	}
+}
+
+// outLoop drains c.out until either it errors writing a packet or done is
+// closed. done, not a closed c.out, is what lets closeChannel's queued
+// disconnect packet be written and outLoop still terminate afterwards:
+// c.out is never closed, since Emit can be called concurrently with a
+// disconnect and must never send on a closed channel.
+func outLoop(c *Channel, m *methods) {
+	for {
+		select {
+		case packet := <-c.out:
+			if err := c.conn.WriteMessage(wsTextMessage, []byte(packet)); err != nil {
+				c.disconnect(err)
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func pinger(c *Channel) {
+	pingInterval := c.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = defaultPingInterval
+	}
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case c.out <- string(engineIOPing):
+			default:
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
@@ -2,11 +2,58 @@ package gosocketio
 
 import (
 	"github.com/integration-system/golang-socketio/transport"
+	"context"
+	"crypto/tls"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// ErrConnectionLost is delivered to the callback of a pending ack whose
+// connection dropped before the server could respond and that was not
+// eligible for replay on reconnect.
+var ErrConnectionLost = errors.New("gosocketio: connection lost before ack was received")
+
+// DialOptions carries transport-level connection settings that are
+// preserved across reconnects: auth headers, TLS configuration and
+// dialing/proxy overrides.
+type DialOptions struct {
+	Headers   http.Header
+	TLSConfig *tls.Config
+	NetDial   func(network, addr string) (net.Conn, error)
+	Proxy     func(*http.Request) (*url.URL, error)
+	// HeaderProvider, when set, is invoked on every dial (including
+	// reconnects) and its result is merged over Headers. Use it for
+	// short-lived tokens that need to be refreshed on each attempt.
+	HeaderProvider func() http.Header
+}
+
+// headers returns the effective header set for a single dial attempt,
+// merging the static Headers with whatever HeaderProvider produces now.
+func (o *DialOptions) headers() http.Header {
+	if o == nil {
+		return nil
+	}
+	h := o.Headers.Clone()
+	if o.HeaderProvider == nil {
+		return h
+	}
+	if h == nil {
+		h = http.Header{}
+	}
+	for k, v := range o.HeaderProvider() {
+		h[k] = v
+	}
+	return h
+}
+
 const (
 	webSocketProtocol       = "ws://"
 	webSocketSecureProtocol = "wss://"
@@ -15,6 +62,14 @@ const (
 
 const (
 	defaultReconnectionTimeout = 3 * time.Second
+	defaultMaxInterval         = 30 * time.Second
+	defaultMultiplier          = 2.0
+	defaultJitterFactor        = 0.2
+	defaultMaxPendingAcks      = 256
+	defaultPendingAckTimeout   = 30 * time.Second
+	// defaultPingInterval/defaultPongTimeout match the Engine.IO v3 defaults.
+	defaultPingInterval = 25 * time.Second
+	defaultPongTimeout  = 20 * time.Second
 )
 
 /**
@@ -23,18 +78,162 @@ Socket.io client representation
 type Client struct {
 	methods
 	Channel
-	tr               transport.Transport
-	rp               *ReconnectionPolicy
-	reconnectChannel chan bool
-	url              string
-	lock             sync.Mutex
-	open             bool
+	tr                transport.Transport
+	rp                *ReconnectionPolicy
+	dialOptions       *DialOptions
+	reconnectChannel  chan bool
+	reconnectAttempts int
+	url               string
+	urls              []string
+	urlIndex          int
+	urlLock           sync.Mutex
+	lock              sync.Mutex
+	open              bool
+
+	onConnectHandler    func(*Client)
+	onDisconnectHandler func(*Client, error)
+	onErrorHandler      func(*Client, error)
+	onRawMessageHandler func([]byte)
+	lifecycleQueue      chan func()
+
+	doneChannel  chan struct{}
+	shutdownOnce sync.Once
+	loopsWG      sync.WaitGroup
+
+	onReconnectedHandler func(*Client)
+	hasConnectedOnce     bool
+	resumeSession        bool
+	pending              *pendingAckTracker
+
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+}
+
+// pendingAck is an emit that is waiting on a server ack. If the
+// connection drops before the ack arrives, it is either replayed on the
+// next successful reconnect (replay == true) or completed with
+// ErrConnectionLost.
+type pendingAck struct {
+	event    string
+	args     interface{}
+	replay   bool
+	deadline time.Time
+	callback func(err error)
+}
+
+// pendingAckTracker is a bounded queue of in-flight acks, keyed so that a
+// client reconnecting far in the future doesn't hold callbacks forever:
+// entries past their deadline are dropped with ErrConnectionLost instead
+// of being replayed.
+type pendingAckTracker struct {
+	mu      sync.Mutex
+	maxSize int
+	timeout time.Duration
+	entries map[uint64]*pendingAck
+	nextID  uint64
+}
+
+func newPendingAckTracker(maxSize int, timeout time.Duration) *pendingAckTracker {
+	if maxSize <= 0 {
+		maxSize = defaultMaxPendingAcks
+	}
+	if timeout <= 0 {
+		timeout = defaultPendingAckTimeout
+	}
+	return &pendingAckTracker{
+		maxSize: maxSize,
+		timeout: timeout,
+		entries: make(map[uint64]*pendingAck),
+	}
+}
+
+func (t *pendingAckTracker) add(event string, args interface{}, replay bool, callback func(err error)) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.entries) >= t.maxSize {
+		return 0, errors.New("gosocketio: pending ack queue full")
+	}
+
+	t.nextID++
+	id := t.nextID
+	t.entries[id] = &pendingAck{
+		event:    event,
+		args:     args,
+		replay:   replay,
+		deadline: time.Now().Add(t.timeout),
+		callback: callback,
+	}
+	return id, nil
+}
+
+func (t *pendingAckTracker) complete(id uint64, err error) {
+	t.mu.Lock()
+	ack, ok := t.entries[id]
+	if ok {
+		delete(t.entries, id)
+	}
+	t.mu.Unlock()
+
+	if ok && ack.callback != nil {
+		ack.callback(err)
+	}
+}
+
+// drain is called after a reconnect: expired or non-replayable entries
+// are completed with ErrConnectionLost, the rest are re-sent through
+// emitWithAck, keeping their tracker entry alive so a second drop before
+// the ack arrives replays them again instead of losing them.
+func (t *pendingAckTracker) drain(emitWithAck func(event string, args interface{}, onAck func(error)) error) {
+	t.mu.Lock()
+	now := time.Now()
+	var toReplay []uint64
+	for id, ack := range t.entries {
+		if !ack.replay || now.After(ack.deadline) {
+			delete(t.entries, id)
+			if ack.callback != nil {
+				go ack.callback(ErrConnectionLost)
+			}
+			continue
+		}
+		toReplay = append(toReplay, id)
+	}
+	t.mu.Unlock()
+
+	for _, id := range toReplay {
+		t.mu.Lock()
+		ack, ok := t.entries[id]
+		t.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		id := id
+		if err := emitWithAck(ack.event, ack.args, func(err error) { t.complete(id, err) }); err != nil {
+			t.complete(id, err)
+		}
+	}
 }
 
 type ReconnectionPolicy struct {
-	Enable              bool
+	Enable bool
+	// InitialInterval is the wait before the first reconnection attempt.
+	// ReconnectionTimeout is kept for backward compatibility and, if set
+	// without InitialInterval, is used as its value.
 	ReconnectionTimeout time.Duration
-	OnReconnectionError func(err error)
+	InitialInterval     time.Duration
+	MaxInterval         time.Duration
+	Multiplier          float64
+	JitterFactor        float64
+	// MaxAttempts caps the number of consecutive reconnection attempts.
+	// 0 means unlimited.
+	MaxAttempts int
+	// OnReconnectionError is called with the URL that failed to dial and
+	// the resulting error, so operators can tell which endpoint is degraded.
+	OnReconnectionError func(url string, err error)
+	// OnReconnectionAbort fires once MaxAttempts is exhausted, right after
+	// the client is marked closed.
+	OnReconnectionAbort func(err error)
 }
 
 type clientBuilder struct {
@@ -53,14 +252,138 @@ func (cb *clientBuilder) EnableReconnection() *clientBuilder {
 
 func (cb *clientBuilder) ReconnectionTimeout(timeout time.Duration) *clientBuilder {
 	cb.client.rp.ReconnectionTimeout = timeout
+	cb.client.rp.InitialInterval = timeout
+	return cb
+}
+
+func (cb *clientBuilder) MaxReconnectionInterval(maxInterval time.Duration) *clientBuilder {
+	cb.client.rp.MaxInterval = maxInterval
+	return cb
+}
+
+func (cb *clientBuilder) ReconnectionMultiplier(multiplier float64) *clientBuilder {
+	cb.client.rp.Multiplier = multiplier
+	return cb
+}
+
+func (cb *clientBuilder) ReconnectionJitterFactor(jitterFactor float64) *clientBuilder {
+	cb.client.rp.JitterFactor = jitterFactor
+	return cb
+}
+
+func (cb *clientBuilder) MaxReconnectionAttempts(maxAttempts int) *clientBuilder {
+	cb.client.rp.MaxAttempts = maxAttempts
 	return cb
 }
 
-func (cb *clientBuilder) OnReconnectionError(handler func(err error)) *clientBuilder {
+func (cb *clientBuilder) OnReconnectionError(handler func(url string, err error)) *clientBuilder {
 	cb.client.rp.OnReconnectionError = handler
 	return cb
 }
 
+func (cb *clientBuilder) OnReconnectionAbort(handler func(err error)) *clientBuilder {
+	cb.client.rp.OnReconnectionAbort = handler
+	return cb
+}
+
+func (cb *clientBuilder) Headers(headers http.Header) *clientBuilder {
+	cb.client.dialOptions.Headers = headers
+	return cb
+}
+
+func (cb *clientBuilder) HeaderProvider(provider func() http.Header) *clientBuilder {
+	cb.client.dialOptions.HeaderProvider = provider
+	return cb
+}
+
+func (cb *clientBuilder) TLSConfig(config *tls.Config) *clientBuilder {
+	cb.client.dialOptions.TLSConfig = config
+	return cb
+}
+
+func (cb *clientBuilder) HTTPProxy(proxy func(*http.Request) (*url.URL, error)) *clientBuilder {
+	cb.client.dialOptions.Proxy = proxy
+	return cb
+}
+
+// OnConnect registers a handler fired after every successful Dial,
+// including reconnects.
+func (cb *clientBuilder) OnConnect(handler func(*Client)) *clientBuilder {
+	cb.client.onConnectHandler = handler
+	return cb
+}
+
+// OnDisconnect registers a handler fired when the read/write loops
+// terminate, with the underlying error (nil on a clean close).
+func (cb *clientBuilder) OnDisconnect(handler func(*Client, error)) *clientBuilder {
+	cb.client.onDisconnectHandler = handler
+	return cb
+}
+
+// OnError registers a handler for transport-level read/write errors that
+// would otherwise be swallowed.
+func (cb *clientBuilder) OnError(handler func(*Client, error)) *clientBuilder {
+	cb.client.onErrorHandler = handler
+	return cb
+}
+
+// OnRawMessage registers a tap invoked with every raw message received,
+// before Engine.IO decoding. Intended for debugging and protocol tracing.
+func (cb *clientBuilder) OnRawMessage(handler func([]byte)) *clientBuilder {
+	cb.client.onRawMessageHandler = handler
+	return cb
+}
+
+// OnReconnected registers a handler fired after a dropped connection is
+// re-established, once the new connection is fully usable, so callers
+// can re-Emit("join", room) and similar resubscriptions. It does not
+// fire on the initial Dial.
+func (cb *clientBuilder) OnReconnected(handler func(*Client)) *clientBuilder {
+	cb.client.onReconnectedHandler = handler
+	return cb
+}
+
+// MaxPendingAcks bounds how many in-flight acked emits are tracked for
+// replay across a reconnect before EmitWithAck starts returning an error.
+func (cb *clientBuilder) MaxPendingAcks(maxPending int) *clientBuilder {
+	cb.client.pending.maxSize = maxPending
+	return cb
+}
+
+// PendingAckTimeout bounds how long a pending ack is kept across
+// reconnects before it is completed with ErrConnectionLost instead of
+// being replayed.
+func (cb *clientBuilder) PendingAckTimeout(timeout time.Duration) *clientBuilder {
+	cb.client.pending.timeout = timeout
+	return cb
+}
+
+// EnableSessionResume makes reconnects send the Engine.IO sid query
+// parameter of the previous session, for servers that implement session
+// resumption.
+func (cb *clientBuilder) EnableSessionResume() *clientBuilder {
+	cb.client.resumeSession = true
+	return cb
+}
+
+// PingInterval sets how often the client pings the server; it is also part
+// of the read deadline (see PongTimeout). Defaults to 25s, matching the
+// Engine.IO v3 default.
+func (cb *clientBuilder) PingInterval(interval time.Duration) *clientBuilder {
+	cb.client.pingInterval = interval
+	return cb
+}
+
+// PongTimeout sets how long after a missed ping the connection is
+// considered dead. The read deadline is PingInterval+PongTimeout,
+// refreshed on every incoming frame, so a half-open connection (common on
+// mobile networks and NATs) is detected instead of waiting for the next
+// failed write. Defaults to 20s, matching the Engine.IO v3 default.
+func (cb *clientBuilder) PongTimeout(timeout time.Duration) *clientBuilder {
+	cb.client.pongTimeout = timeout
+	return cb
+}
+
 func (cb *clientBuilder) On(event string, f interface{}, onSubError func(event string, err error)) *clientBuilder {
 	if err := cb.client.On(event, f); err != nil && onSubError != nil {
 		onSubError(event, err)
@@ -74,6 +397,7 @@ func (cb *clientBuilder) UnsafeClient() *Client {
 
 func (cb *clientBuilder) BuildToConnect(targetUrl string) *Client {
 	cb.client.initChannel()
+	cb.client.initLifecycle()
 	if cb.client.rp.Enable {
 		cb.client.runReconnectionTask()
 	}
@@ -81,17 +405,190 @@ func (cb *clientBuilder) BuildToConnect(targetUrl string) *Client {
 	return cb.client
 }
 
+// BuildToConnectAny configures the client with a list of candidate
+// endpoints. Dial connects to the first one; if the reconnection policy
+// is enabled, a failed reconnection attempt rotates to the next URL in
+// the list (round-robin) so a client with a primary and fallback hosts
+// survives an outage of one of them.
+func (cb *clientBuilder) BuildToConnectAny(urls ...string) *Client {
+	cb.client.initChannel()
+	cb.client.initLifecycle()
+	cb.client.urls = urls
+	if cb.client.rp.Enable {
+		cb.client.runReconnectionTask()
+	}
+	if len(urls) > 0 {
+		cb.client.url = urls[0]
+	}
+	return cb.client
+}
+
 func NewClientBuilder() *clientBuilder {
 	c := &Client{
 		tr: transport.GetDefaultWebsocketTransport(),
 		rp: &ReconnectionPolicy{
 			Enable: false,
 		},
+		dialOptions: &DialOptions{},
+		doneChannel: make(chan struct{}),
+		pending:     newPendingAckTracker(defaultMaxPendingAcks, defaultPendingAckTimeout),
 	}
 	c.initMethods()
 	return &clientBuilder{client: c}
 }
 
+// initLifecycle wires up the dedicated dispatch goroutine for lifecycle
+// callbacks (OnConnect/OnDisconnect/OnError) and the Channel-level
+// disconnection hook that feeds it, so a slow user handler does not block
+// the read/write loops while fewer than 64 callbacks are queued; once the
+// queue is full, emitLifecycle blocks until the handler catches up. It is safe to call once per client and
+// survives reconnects.
+func (c *Client) initLifecycle() {
+	c.lifecycleQueue = make(chan func(), 64)
+	go c.dispatchLifecycle()
+
+	c.onError = c.reportError
+	c.onRawMessage = c.tapRawMessage
+
+	c.onDisconnection = func(channel *Channel, err error) {
+		c.emitLifecycle(func() {
+			if c.onDisconnectHandler != nil {
+				c.onDisconnectHandler(c, err)
+			}
+		})
+		if c.isOpen() {
+			c.triggerReconnect()
+		}
+	}
+}
+
+// isOpen reads c.open under c.lock, the same lock Shutdown writes it
+// under.
+func (c *Client) isOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.open
+}
+
+// triggerReconnect hands off to the reconnection goroutine without ever
+// sending on a channel that Shutdown/Close may be closing concurrently:
+// it races the send against doneChannel instead of relying on c.open,
+// which previously could be read as true a moment before Close closed
+// reconnectChannel out from under it.
+func (c *Client) triggerReconnect() {
+	if c.reconnectChannel == nil {
+		return
+	}
+	select {
+	case c.reconnectChannel <- true:
+	case <-c.doneChannel:
+	}
+}
+
+// signalDone closes doneChannel exactly once, waking the reconnection
+// goroutine and any in-flight triggerReconnect call.
+func (c *Client) signalDone() {
+	c.shutdownOnce.Do(func() {
+		close(c.doneChannel)
+	})
+}
+
+func (c *Client) dispatchLifecycle() {
+	for fn := range c.lifecycleQueue {
+		fn()
+	}
+}
+
+func (c *Client) emitLifecycle(fn func()) {
+	if fn == nil || c.lifecycleQueue == nil {
+		return
+	}
+	c.lifecycleQueue <- fn
+}
+
+// reportError routes a transport-level read/write error to OnError,
+// instead of silently swallowing it.
+func (c *Client) reportError(err error) {
+	c.emitLifecycle(func() {
+		if c.onErrorHandler != nil {
+			c.onErrorHandler(c, err)
+		}
+	})
+}
+
+// tapRawMessage feeds OnRawMessage with the raw bytes received on the
+// wire, before Engine.IO decoding.
+func (c *Client) tapRawMessage(data []byte) {
+	if c.onRawMessageHandler == nil {
+		return
+	}
+	c.onRawMessageHandler(data)
+}
+
+// EmitWithAck emits an event and tracks it as a pending ack, completing
+// callback as soon as the server's real Socket.IO ack packet arrives. If
+// the connection drops before that, the emit is replayed after the next
+// successful reconnect; if that reconnect comes after PendingAckTimeout
+// has elapsed, callback is invoked with ErrConnectionLost instead. The
+// timeout is only checked on reconnect: an emit on a connection that stays
+// up is not timed out.
+func (c *Client) EmitWithAck(event string, args interface{}, callback func(err error)) error {
+	id, err := c.pending.add(event, args, true, callback)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Channel.emitWithAck(event, args, func(err error) { c.pending.complete(id, err) }); err != nil {
+		c.pending.complete(id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) effectivePingInterval() time.Duration {
+	if c.pingInterval <= 0 {
+		return defaultPingInterval
+	}
+	return c.pingInterval
+}
+
+func (c *Client) effectivePongTimeout() time.Duration {
+	if c.pongTimeout <= 0 {
+		return defaultPongTimeout
+	}
+	return c.pongTimeout
+}
+
+// currentURL returns the endpoint the next Dial should use. It is the
+// single place that reads c.url, so it is always consistent with
+// UpdateURL/nextURL writing it under the same lock.
+func (c *Client) currentURL() string {
+	c.urlLock.Lock()
+	defer c.urlLock.Unlock()
+	return c.url
+}
+
+// dialURL returns the endpoint to dial, appending the previous session's
+// Engine.IO sid when session resume is enabled and this is a reconnect,
+// for servers that implement session resumption.
+func (c *Client) dialURL() string {
+	url := c.currentURL()
+	if !c.resumeSession || !c.hasConnectedOnce {
+		return url
+	}
+
+	sid := c.Id
+	if sid == "" {
+		return url
+	}
+
+	if strings.Contains(url, "?") {
+		return url + "&sid=" + sid
+	}
+	return url + "?sid=" + sid
+}
+
 /**
 connect to host and initialise socket.io protocol
 
@@ -101,64 +598,205 @@ ws://myserver.com/socket.io/?EIO=3&transport=websocket
 You can use GetUrlByHost for generating correct url
 */
 func (c *Client) Dial() error {
+	return c.DialContext(context.Background())
+}
+
+// DialContext behaves like Dial but honors ctx cancellation while the
+// underlying websocket handshake is in flight.
+func (c *Client) DialContext(ctx context.Context) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	// Shutdown closes doneChannel inside this same critical section
+	// before it ever waits on loopsWG, so observing it still open here
+	// guarantees no Shutdown loopsWG.Wait() call can be in flight yet - it
+	// is safe to Add below. Checked and not just trusted to have been
+	// checked earlier, since a reconnect attempt racing Shutdown may
+	// reach here after Shutdown already ran.
+	select {
+	case <-c.doneChannel:
+		return errors.New("gosocketio: client is shut down")
+	default:
+	}
+
+	// Join the previous generation's inLoop/outLoop/pinger before reusing
+	// their channels: resetForDial below replaces c.out/c.done, and a
+	// goroutine still selecting on the old ones when that happens would
+	// leak forever instead of observing its own generation's close. This
+	// is a no-op on the very first Dial, since loopsWG starts at zero.
+	// The previous generation is guaranteed to actually finish: its
+	// disconnect() (the only way a generation ends) already closed its
+	// done channel before we ever got here, which ends outLoop and pinger,
+	// and inLoop returns once its read fails, at the latest when the read
+	// deadline fires.
+	c.loopsWG.Wait()
+
 	var err error
-	c.conn, err = c.tr.Connect(c.url)
+	c.conn, err = c.tr.ConnectContext(ctx, c.dialURL(), transport.DialOptions{
+		Headers:   c.dialOptions.headers(),
+		TLSConfig: c.dialOptions.TLSConfig,
+		NetDial:   c.dialOptions.NetDial,
+		Proxy:     c.dialOptions.Proxy,
+	})
 	if err != nil {
 		return err
 	}
 
 	c.SetAlive(true)
+	c.resetForDial()
+
+	c.Channel.PingInterval = c.effectivePingInterval()
+	c.Channel.PongTimeout = c.effectivePongTimeout()
 
-	go inLoop(&c.Channel, &c.methods)
-	go outLoop(&c.Channel, &c.methods)
-	go pinger(&c.Channel)
+	c.loopsWG.Add(3)
+	go func() { defer c.loopsWG.Done(); inLoop(&c.Channel, &c.methods) }()
+	go func() { defer c.loopsWG.Done(); outLoop(&c.Channel, &c.methods) }()
+	go func() { defer c.loopsWG.Done(); pinger(&c.Channel) }()
 
 	c.open = true
+	c.reconnectAttempts = 0
+
+	reconnected := c.hasConnectedOnce
+	c.hasConnectedOnce = true
+
+	c.emitLifecycle(func() {
+		if c.onConnectHandler != nil {
+			c.onConnectHandler(c)
+		}
+	})
+
+	if reconnected {
+		c.pending.drain(c.Channel.emitWithAck)
+		c.emitLifecycle(func() {
+			if c.onReconnectedHandler != nil {
+				c.onReconnectedHandler(c)
+			}
+		})
+	}
 
 	return nil
 }
 
+// UpdateURL replaces the endpoint(s) the client dials. It is goroutine-safe
+// and takes effect on the next reconnect attempt; it never touches an
+// in-flight Dial. Pass a single URL, or several to enable round-robin
+// failover as with BuildToConnectAny.
+func (c *Client) UpdateURL(urls ...string) {
+	if len(urls) == 0 {
+		return
+	}
+
+	c.urlLock.Lock()
+	defer c.urlLock.Unlock()
+
+	c.urls = urls
+	c.urlIndex = 0
+	c.url = urls[0]
+}
+
+// nextURL rotates to the next candidate endpoint, round-robin, and returns
+// it. If no failover list was configured it just returns the current URL.
+func (c *Client) nextURL() string {
+	c.urlLock.Lock()
+	defer c.urlLock.Unlock()
+
+	if len(c.urls) == 0 {
+		return c.url
+	}
+
+	c.urlIndex = (c.urlIndex + 1) % len(c.urls)
+	c.url = c.urls[c.urlIndex]
+	return c.url
+}
+
 /**
 Close client connection
 */
 func (c *Client) Close() {
+	_ = c.Shutdown(context.Background())
+}
+
+// Shutdown gracefully tears the client down: it stops the reconnection
+// goroutine, sends a Socket.IO disconnect packet, closes the websocket
+// with a proper close frame, and waits for inLoop/outLoop/pinger to exit
+// or ctx to expire, whichever comes first.
+func (c *Client) Shutdown(ctx context.Context) error {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	if !c.open {
+		c.signalDone()
+		c.lock.Unlock()
+		return nil
+	}
+	c.open = false
+	c.signalDone()
+	c.lock.Unlock()
+
+	// closeChannel runs outside the lock: it synchronously invokes
+	// onDisconnection, which takes c.lock itself via isOpen() - holding
+	// it here would deadlock. doneChannel is already closed above, so a
+	// concurrent DialContext cannot sneak in an Add() on loopsWG after
+	// this point regardless.
+	closeChannel(&c.Channel, &c.methods)
+
+	loopsDone := make(chan struct{})
+	go func() {
+		c.loopsWG.Wait()
+		close(loopsDone)
+	}()
 
-	if c.open {
-		c.open = false
-		close(c.reconnectChannel)
-		closeChannel(&c.Channel, &c.methods)
+	select {
+	case <-loopsDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 func (c *Client) runReconnectionTask() {
 	c.reconnectChannel = make(chan bool)
-	c.onDisconnection = func(channel *Channel) {
-		if c.open {
-			c.reconnectChannel <- true
-		}
-	}
 	go func() {
 		for {
-			if _, open := <-c.reconnectChannel; !open {
+			select {
+			case <-c.doneChannel:
 				return
+			case <-c.reconnectChannel:
 			}
+			c.reconnectAttempts = 0
 			connected := false
+			var lastErr error
 			for !connected {
-				timeout := c.rp.ReconnectionTimeout
-				if timeout <= 0 {
-					timeout = defaultReconnectionTimeout
+				select {
+				case <-c.doneChannel:
+					return
+				default:
+				}
+
+				if c.rp.MaxAttempts > 0 && c.reconnectAttempts >= c.rp.MaxAttempts {
+					c.lock.Lock()
+					c.open = false
+					c.lock.Unlock()
+					// MaxAttempts exhaustion marks the client closed, same
+					// as an explicit Shutdown: park this goroutine for good
+					// instead of leaving it alive waiting on a
+					// reconnectChannel send nothing will ever trigger again.
+					c.signalDone()
+					if c.rp.OnReconnectionAbort != nil {
+						c.rp.OnReconnectionAbort(lastErr)
+					}
+					break
 				}
-				time.Sleep(timeout)
+
+				time.Sleep(nextBackoff(c.rp, c.reconnectAttempts))
+				c.reconnectAttempts++
+
+				failedURL := c.currentURL()
 				err := c.Dial()
 				if err != nil {
+					lastErr = err
 					if c.rp.OnReconnectionError != nil {
-						c.rp.OnReconnectionError(err)
+						c.rp.OnReconnectionError(failedURL, err)
 					}
+					c.nextURL()
 				} else {
 					connected = true
 				}
@@ -167,6 +805,44 @@ func (c *Client) runReconnectionTask() {
 	}()
 }
 
+// nextBackoff computes the wait before the reconnection attempt numbered
+// attempt (0-based): min(MaxInterval, InitialInterval*Multiplier^attempt),
+// perturbed by ±JitterFactor*interval to avoid thundering herds when many
+// clients reconnect after a shared outage.
+func nextBackoff(rp *ReconnectionPolicy, attempt int) time.Duration {
+	initial := rp.InitialInterval
+	if initial <= 0 {
+		initial = rp.ReconnectionTimeout
+	}
+	if initial <= 0 {
+		initial = defaultReconnectionTimeout
+	}
+	maxInterval := rp.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxInterval
+	}
+	multiplier := rp.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultMultiplier
+	}
+
+	interval := float64(initial) * math.Pow(multiplier, float64(attempt))
+	if interval > float64(maxInterval) {
+		interval = float64(maxInterval)
+	}
+
+	jitterFactor := rp.JitterFactor
+	if jitterFactor > 0 {
+		jitter := interval * jitterFactor
+		interval += (rand.Float64()*2 - 1) * jitter
+		if interval < 0 {
+			interval = 0
+		}
+	}
+
+	return time.Duration(interval)
+}
+
 /**
 Get ws/wss url by host and port
 */
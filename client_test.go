@@ -0,0 +1,176 @@
+package gosocketio
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNextBackoffCapsAtMaxInterval(t *testing.T) {
+	rp := &ReconnectionPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     1 * time.Second,
+		Multiplier:      2.0,
+	}
+
+	got := nextBackoff(rp, 10)
+	if got != rp.MaxInterval {
+		t.Fatalf("nextBackoff(attempt=10) = %v, want the MaxInterval cap %v", got, rp.MaxInterval)
+	}
+}
+
+func TestNextBackoffGrowsExponentially(t *testing.T) {
+	rp := &ReconnectionPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2.0,
+	}
+
+	for attempt, want := range map[int]time.Duration{
+		0: 100 * time.Millisecond,
+		1: 200 * time.Millisecond,
+		2: 400 * time.Millisecond,
+		3: 800 * time.Millisecond,
+	} {
+		if got := nextBackoff(rp, attempt); got != want {
+			t.Fatalf("nextBackoff(attempt=%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestNextBackoffJitterStaysWithinBounds(t *testing.T) {
+	rp := &ReconnectionPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2.0,
+		JitterFactor:    0.2,
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		base := float64(rp.InitialInterval) * math.Pow(rp.Multiplier, float64(attempt))
+		low := time.Duration(base * (1 - rp.JitterFactor))
+		high := time.Duration(base * (1 + rp.JitterFactor))
+
+		for i := 0; i < 20; i++ {
+			got := nextBackoff(rp, attempt)
+			if got < low || got > high {
+				t.Fatalf("nextBackoff(attempt=%d) = %v, want within [%v, %v]", attempt, got, low, high)
+			}
+		}
+	}
+}
+
+func TestNextURLRoundRobin(t *testing.T) {
+	c := NewClientBuilder().BuildToConnectAny("a", "b", "c")
+
+	if got := c.currentURL(); got != "a" {
+		t.Fatalf("initial currentURL() = %q, want %q", got, "a")
+	}
+
+	want := []string{"b", "c", "a", "b"}
+	for i, w := range want {
+		if got := c.nextURL(); got != w {
+			t.Fatalf("nextURL() call #%d = %q, want %q", i+1, got, w)
+		}
+	}
+}
+
+func TestNextURLWithoutFailoverListReturnsCurrent(t *testing.T) {
+	c := NewClientBuilder().BuildToConnect("only")
+
+	for i := 0; i < 3; i++ {
+		if got := c.nextURL(); got != "only" {
+			t.Fatalf("nextURL() call #%d = %q, want %q", i+1, got, "only")
+		}
+	}
+}
+
+func TestPendingAckTrackerDrainReplaysAndKeepsEntryUntilAcked(t *testing.T) {
+	tracker := newPendingAckTracker(defaultMaxPendingAcks, time.Minute)
+
+	acked := make(chan error, 1)
+	id, err := tracker.add("greet", "hi", true, func(err error) { acked <- err })
+	if err != nil {
+		t.Fatalf("add() returned unexpected error: %v", err)
+	}
+
+	var replayedEvent string
+	var replayedOnAck func(error)
+	drained := func(event string, args interface{}, onAck func(error)) error {
+		replayedEvent = event
+		replayedOnAck = onAck
+		return nil
+	}
+
+	tracker.drain(drained)
+
+	if replayedEvent != "greet" {
+		t.Fatalf("drain() replayed event %q, want %q", replayedEvent, "greet")
+	}
+	if _, stillPending := tracker.entries[id]; !stillPending {
+		t.Fatal("drain() removed the entry before the ack arrived; a second drop would now lose it")
+	}
+
+	replayedOnAck(nil)
+
+	if _, stillPending := tracker.entries[id]; stillPending {
+		t.Fatal("entry was not removed once the replayed emit was acked")
+	}
+	select {
+	case err := <-acked:
+		if err != nil {
+			t.Fatalf("callback got err=%v, want nil", err)
+		}
+	default:
+		t.Fatal("callback was never invoked")
+	}
+}
+
+func TestPendingAckTrackerDrainExpiresPastDeadline(t *testing.T) {
+	tracker := newPendingAckTracker(defaultMaxPendingAcks, time.Millisecond)
+
+	done := make(chan error, 1)
+	if _, err := tracker.add("greet", nil, true, func(err error) { done <- err }); err != nil {
+		t.Fatalf("add() returned unexpected error: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	tracker.drain(func(event string, args interface{}, onAck func(error)) error {
+		t.Fatal("drain() replayed an entry past its deadline")
+		return nil
+	})
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrConnectionLost) {
+			t.Fatalf("callback got err=%v, want ErrConnectionLost", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("callback was never invoked for an expired entry")
+	}
+}
+
+func TestPendingAckTrackerDrainDropsNonReplayable(t *testing.T) {
+	tracker := newPendingAckTracker(defaultMaxPendingAcks, time.Minute)
+
+	done := make(chan error, 1)
+	if _, err := tracker.add("greet", nil, false, func(err error) { done <- err }); err != nil {
+		t.Fatalf("add() returned unexpected error: %v", err)
+	}
+
+	tracker.drain(func(event string, args interface{}, onAck func(error)) error {
+		t.Fatal("drain() replayed a non-replayable entry")
+		return nil
+	})
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrConnectionLost) {
+			t.Fatalf("callback got err=%v, want ErrConnectionLost", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("callback was never invoked for a non-replayable entry")
+	}
+}
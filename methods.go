@@ -0,0 +1,62 @@
+package gosocketio
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var errHandlerMustBeFunc = errors.New("gosocketio: On handler must be a function")
+
+// methods holds the application-level event handlers registered via On.
+// A handler may take (*Channel), or (*Channel, T) where T is whatever
+// type the caller wants the event's argument decoded into.
+type methods struct {
+	handlersLock sync.RWMutex
+	handlers     map[string]reflect.Value
+}
+
+func (m *methods) initMethods() {
+	m.handlers = make(map[string]reflect.Value)
+}
+
+func (m *methods) On(event string, f interface{}) error {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func {
+		return errHandlerMustBeFunc
+	}
+
+	m.handlersLock.Lock()
+	m.handlers[event] = v
+	m.handlersLock.Unlock()
+	return nil
+}
+
+// callEvent invokes the handler registered for event, if any, decoding
+// args into the handler's second parameter type when it has one.
+func (m *methods) callEvent(c *Channel, event string, args interface{}) {
+	m.handlersLock.RLock()
+	handler, ok := m.handlers[event]
+	m.handlersLock.RUnlock()
+	if !ok {
+		return
+	}
+
+	t := handler.Type()
+	in := make([]reflect.Value, 0, t.NumIn())
+	if t.NumIn() > 0 {
+		in = append(in, reflect.ValueOf(c))
+	}
+	if t.NumIn() > 1 {
+		argType := t.In(1)
+		argVal := reflect.New(argType)
+		if args != nil {
+			if raw, err := json.Marshal(args); err == nil {
+				_ = json.Unmarshal(raw, argVal.Interface())
+			}
+		}
+		in = append(in, argVal.Elem())
+	}
+	handler.Call(in)
+}
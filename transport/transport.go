@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Connection is the minimal set of operations the rest of the package
+// needs from an established transport connection.
+type Connection interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+// DialOptions carries the per-dial settings a Transport should apply:
+// auth headers, TLS configuration, and dialing/proxy overrides. It is
+// the transport-level counterpart of the client's DialOptions, resolved
+// down to a plain header set (no HeaderProvider) by the caller.
+type DialOptions struct {
+	Headers   http.Header
+	TLSConfig *tls.Config
+	NetDial   func(network, addr string) (net.Conn, error)
+	Proxy     func(*http.Request) (*url.URL, error)
+}
+
+// Transport establishes connections to a Socket.IO/Engine.IO endpoint.
+type Transport interface {
+	// Connect dials targetUrl with no special options. Kept for
+	// back-compat with callers that predate DialOptions.
+	Connect(targetUrl string) (Connection, error)
+
+	// ConnectWithOptions dials targetUrl applying opts.
+	ConnectWithOptions(targetUrl string, opts DialOptions) (Connection, error)
+
+	// ConnectContext behaves like ConnectWithOptions but aborts the dial
+	// if ctx is done before the handshake completes.
+	ConnectContext(ctx context.Context, targetUrl string, opts DialOptions) (Connection, error)
+}
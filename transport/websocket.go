@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const defaultHandshakeTimeout = 10 * time.Second
+
+// WebsocketTransport is the default Transport, backed by gorilla/websocket.
+type WebsocketTransport struct {
+	HandshakeTimeout time.Duration
+}
+
+// GetDefaultWebsocketTransport returns a WebsocketTransport configured
+// with sane defaults.
+func GetDefaultWebsocketTransport() *WebsocketTransport {
+	return &WebsocketTransport{HandshakeTimeout: defaultHandshakeTimeout}
+}
+
+func (t *WebsocketTransport) Connect(targetUrl string) (Connection, error) {
+	return t.ConnectWithOptions(targetUrl, DialOptions{})
+}
+
+func (t *WebsocketTransport) ConnectWithOptions(targetUrl string, opts DialOptions) (Connection, error) {
+	return t.ConnectContext(context.Background(), targetUrl, opts)
+}
+
+func (t *WebsocketTransport) ConnectContext(ctx context.Context, targetUrl string, opts DialOptions) (Connection, error) {
+	handshakeTimeout := t.HandshakeTimeout
+	if handshakeTimeout <= 0 {
+		handshakeTimeout = defaultHandshakeTimeout
+	}
+
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: handshakeTimeout,
+		TLSClientConfig:  opts.TLSConfig,
+		Proxy:            opts.Proxy,
+	}
+	if opts.NetDial != nil {
+		dialer.NetDial = opts.NetDial
+	}
+
+	conn, _, err := dialer.DialContext(ctx, targetUrl, opts.Headers)
+	if err != nil {
+		return nil, err
+	}
+	return &wsConnection{Conn: conn}, nil
+}
+
+// wsConnection adapts *websocket.Conn to Connection, additionally making
+// Close send a proper close frame before tearing down the socket.
+type wsConnection struct {
+	*websocket.Conn
+}
+
+func (c *wsConnection) Close() error {
+	_ = c.Conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second),
+	)
+	return c.Conn.Close()
+}